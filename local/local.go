@@ -1,85 +1,160 @@
-package local
-
-import (
-	"errors"
-	"io"
-	"io/fs"
-	"os"
-)
-
-type localProducer struct{}
-
-func NewProducer() (*localProducer, error) {
-	return new(localProducer), nil
-}
-
-func (p *localProducer) Ping(_ string) error {
-	return nil
-}
-
-func (*localProducer) Close() error {
-	return nil
-}
-
-func (*localProducer) Create(path string) (io.WriteCloser, error) {
-	return os.Create(path)
-}
-
-func (*localProducer) MakedirAll(path string) error {
-	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
-		return os.MkdirAll(path, 0666)
-	}
-	return nil
-}
-
-func (*localProducer) ReadFile(path string) (io.ReadCloser, error) {
-	return os.Open(path)
-}
-
-func (p *localProducer) SaveFile(path string, reader io.ReadCloser) error {
-	bytes, err := io.ReadAll(reader)
-	if err != nil {
-		return err
-	}
-	// file, err := os.OpenFile()
-	return os.WriteFile(path, bytes, 0777)
-}
-
-func (*localProducer) ReadDir(path string) ([]fs.FileInfo, error) {
-	dir, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	return dir.Readdir(-1)
-}
-
-func (*localProducer) Remove(path string) error {
-	return os.Remove(path)
-}
-
-func (*localProducer) RemoveAll(path string) error {
-	return os.RemoveAll(path)
-}
-
-func (*localProducer) Rename(oldname, newname string) error {
-	return os.Rename(oldname, newname)
-}
-
-func (*localProducer) DeleteFile(path string) error {
-	return os.Remove(path)
-}
-
-func (*localProducer) MakeDir(path string) error {
-	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
-		return os.Mkdir(path, 0700)
-	}
-	return nil
-}
-
-func (*localProducer) DeleteDir(path string) error {
-	return os.Remove(path)
-}
-
-func (p *localProducer) Stat(path string) (fs.FileInfo, error) {
-	return os.Stat(path)
-}
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+
+	producer "github.com/NuclearLouse/file-producer"
+)
+
+func init() {
+	producer.Register("file", func(u *url.URL) (producer.Producer, error) {
+		return NewProducer()
+	})
+}
+
+type localProducer struct{}
+
+func NewProducer() (*localProducer, error) {
+	return new(localProducer), nil
+}
+
+func (p *localProducer) Ping(_ string) error {
+	return nil
+}
+
+func (*localProducer) Close() error {
+	return nil
+}
+
+// Create opens path for writing, truncating it if it already exists.
+// Canceling ctx closes the file to unblock a write in progress.
+func (*localProducer) Create(ctx context.Context, path string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &cancelFile{file, watchCancel(ctx, file)}, nil
+}
+
+// OpenReader opens path for reading starting at offset. Canceling ctx closes
+// the file to unblock a read in progress.
+func (*localProducer) OpenReader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if offset != 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return &cancelFile{file, watchCancel(ctx, file)}, nil
+}
+
+// watchCancel closes closer as soon as ctx is done, so a blocked read or
+// write on it returns instead of hanging forever. The returned channel must
+// be closed once the caller is done with closer, to stop the goroutine.
+func watchCancel(ctx context.Context, closer io.Closer) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			closer.Close()
+		case <-done:
+		}
+	}()
+	return done
+}
+
+// cancelFile wraps *os.File so Close also stops the goroutine started by
+// watchCancel.
+type cancelFile struct {
+	*os.File
+	done chan struct{}
+}
+
+func (f *cancelFile) Close() error {
+	close(f.done)
+	return f.File.Close()
+}
+
+func (*localProducer) MakedirAll(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return os.MkdirAll(path, 0666)
+	}
+	return nil
+}
+
+func (*localProducer) ReadFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// SaveFile streams reader to path without buffering it in memory.
+func (p *localProducer) SaveFile(path string, reader io.ReadCloser) error {
+	file, err := p.Create(context.Background(), path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if reader == nil {
+		return nil
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(file, reader)
+	return err
+}
+
+func (*localProducer) ReadDir(path string) ([]fs.FileInfo, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return dir.Readdir(-1)
+}
+
+func (*localProducer) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (*localProducer) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (*localProducer) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+func (*localProducer) DeleteFile(path string) error {
+	return os.Remove(path)
+}
+
+func (*localProducer) MakeDir(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return os.Mkdir(path, 0700)
+	}
+	return nil
+}
+
+func (*localProducer) DeleteDir(path string) error {
+	return os.Remove(path)
+}
+
+func (p *localProducer) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(path)
+}
+
+func (p *localProducer) Walk(root string, fn producer.WalkFunc) error {
+	return producer.Walk(p, root, fn)
+}
+
+func (p *localProducer) WalkN(root string, workers int, fn producer.WalkFunc) error {
+	return producer.WalkN(p, root, workers, fn)
+}
@@ -0,0 +1,529 @@
+// Package memfs implements producer.Producer entirely in memory, so code
+// written against the Producer interface can be unit-tested without a real
+// FTP/SFTP server or a scratch directory on disk.
+package memfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	producer "github.com/NuclearLouse/file-producer"
+)
+
+func init() {
+	producer.Register("mem", func(u *url.URL) (producer.Producer, error) {
+		return NewProducer(), nil
+	})
+}
+
+// removeConcurrentWorkers bounds the parallelism producer.RemoveAllConcurrent
+// uses when walking and deleting a tree. memfs has no network round trips to
+// pipeline, but keeping it the same as the remote backends exercises callers
+// the same way in tests.
+const removeConcurrentWorkers = 8
+
+// Producer is an in-memory, concurrency-safe producer.Producer, with
+// optional fault injection for exercising error paths in tests.
+type Producer struct {
+	mu   sync.RWMutex
+	root *node
+
+	faultsMu  sync.Mutex
+	readErrs  map[string]error
+	writeErrs map[string]error
+	latency   time.Duration
+}
+
+// node is a single file or directory in the in-memory tree.
+type node struct {
+	name     string
+	isDir    bool
+	mode     fs.FileMode
+	modTime  time.Time
+	content  []byte
+	children map[string]*node
+}
+
+// NewProducer returns an empty in-memory Producer, rooted at "/".
+func NewProducer() *Producer {
+	return &Producer{
+		root: &node{
+			name:     "/",
+			isDir:    true,
+			mode:     fs.ModeDir | 0755,
+			modTime:  time.Now(),
+			children: make(map[string]*node),
+		},
+		readErrs:  make(map[string]error),
+		writeErrs: make(map[string]error),
+	}
+}
+
+// SetReadErr makes every read of path (ReadFile, OpenReader, ReadDir, Stat)
+// fail with err, until cleared by passing a nil err.
+func (p *Producer) SetReadErr(path string, err error) {
+	p.faultsMu.Lock()
+	defer p.faultsMu.Unlock()
+	if err == nil {
+		delete(p.readErrs, clean(path))
+		return
+	}
+	p.readErrs[clean(path)] = err
+}
+
+// SetWriteErr makes SaveFile, Create and MakeDir on path fail with err,
+// until cleared by passing a nil err. MakedirAll, Rename, Remove, DeleteFile,
+// DeleteDir and RemoveAll do not check it.
+func (p *Producer) SetWriteErr(path string, err error) {
+	p.faultsMu.Lock()
+	defer p.faultsMu.Unlock()
+	if err == nil {
+		delete(p.writeErrs, clean(path))
+		return
+	}
+	p.writeErrs[clean(path)] = err
+}
+
+// SetLatency makes Ping, Stat, ReadFile, OpenReader, Create, SaveFile,
+// MakeDir and ReadDir sleep d before running, to simulate a slow remote.
+// MakedirAll, Rename, Remove, DeleteFile and DeleteDir do not sleep.
+func (p *Producer) SetLatency(d time.Duration) {
+	p.faultsMu.Lock()
+	p.latency = d
+	p.faultsMu.Unlock()
+}
+
+// Entry is a read-only snapshot of one file or directory in the tree
+// returned by Fs, for asserting on in tests.
+type Entry struct {
+	Name     string
+	IsDir    bool
+	Mode     fs.FileMode
+	ModTime  time.Time
+	Content  []byte
+	Children map[string]*Entry
+}
+
+// Fs returns a snapshot of the current tree, rooted at "/", for assertions
+// in tests.
+func (p *Producer) Fs() *Entry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return snapshot(p.root)
+}
+
+func snapshot(n *node) *Entry {
+	e := &Entry{
+		Name:    n.name,
+		IsDir:   n.isDir,
+		Mode:    n.mode,
+		ModTime: n.modTime,
+		Content: append([]byte(nil), n.content...),
+	}
+	if n.isDir {
+		e.Children = make(map[string]*Entry, len(n.children))
+		for name, child := range n.children {
+			e.Children[name] = snapshot(child)
+		}
+	}
+	return e
+}
+
+func (p *Producer) readErr(path string) error {
+	p.faultsMu.Lock()
+	defer p.faultsMu.Unlock()
+	return p.readErrs[clean(path)]
+}
+
+func (p *Producer) writeErr(path string) error {
+	p.faultsMu.Lock()
+	defer p.faultsMu.Unlock()
+	return p.writeErrs[clean(path)]
+}
+
+func (p *Producer) delay() {
+	p.faultsMu.Lock()
+	d := p.latency
+	p.faultsMu.Unlock()
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func clean(p string) string {
+	return path.Clean("/" + p)
+}
+
+func split(p string) []string {
+	p = clean(p)
+	if p == "/" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+// lookup finds the node at path, without locking.
+func (p *Producer) lookup(path string) (*node, error) {
+	n := p.root
+	for _, part := range split(path) {
+		if !n.isDir {
+			return nil, fs.ErrInvalid
+		}
+		child, ok := n.children[part]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		n = child
+	}
+	return n, nil
+}
+
+// parent finds the parent directory of path and the leaf name, without
+// locking.
+func (p *Producer) parent(path string) (*node, string, error) {
+	parts := split(path)
+	if len(parts) == 0 {
+		return nil, "", fs.ErrInvalid
+	}
+	dir, err := p.lookup(strings.Join(parts[:len(parts)-1], "/"))
+	if err != nil {
+		return nil, "", err
+	}
+	if !dir.isDir {
+		return nil, "", fs.ErrInvalid
+	}
+	return dir, parts[len(parts)-1], nil
+}
+
+func (p *Producer) Ping(_ string) error {
+	p.delay()
+	return nil
+}
+
+func (p *Producer) Close() error {
+	return nil
+}
+
+func (p *Producer) Stat(path string) (fs.FileInfo, error) {
+	p.delay()
+	if err := p.readErr(path); err != nil {
+		return nil, err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n, err := p.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(), nil
+}
+
+func (p *Producer) ReadFile(path string) (io.ReadCloser, error) {
+	return p.OpenReader(context.Background(), path, 0)
+}
+
+// Create returns a streaming writer over an in-memory buffer; the buffer
+// replaces the file's content when the writer is closed. Canceling ctx
+// before Close discards the write.
+func (p *Producer) Create(ctx context.Context, path string) (io.WriteCloser, error) {
+	p.delay()
+	if err := p.writeErr(path); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	dir, name, err := p.parent(path)
+	if err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+	p.mu.Unlock()
+
+	return &writer{p: p, dir: dir, name: name, ctx: ctx}, nil
+}
+
+// OpenReader returns a snapshot of the file's content at the time it is
+// called, as of offset.
+func (p *Producer) OpenReader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	p.delay()
+	if err := p.readErr(path); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	n, err := p.lookup(path)
+	if err != nil {
+		p.mu.RUnlock()
+		return nil, err
+	}
+	if n.isDir {
+		p.mu.RUnlock()
+		return nil, fs.ErrInvalid
+	}
+	if offset < 0 || offset > int64(len(n.content)) {
+		p.mu.RUnlock()
+		return nil, fs.ErrInvalid
+	}
+	content := append([]byte(nil), n.content[offset:]...)
+	p.mu.RUnlock()
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// SaveFile streams reader to path without buffering the whole payload
+// outside of the in-memory file it ends up in.
+func (p *Producer) SaveFile(path string, reader io.ReadCloser) error {
+	w, err := p.Create(context.Background(), path)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if reader == nil {
+		return nil
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(w, reader)
+	return err
+}
+
+func (p *Producer) DeleteFile(path string) error {
+	return p.Remove(path)
+}
+
+func (p *Producer) MakeDir(path string) error {
+	p.delay()
+	if err := p.writeErr(path); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dir, name, err := p.parent(path)
+	if err != nil {
+		return err
+	}
+	if _, exists := dir.children[name]; exists {
+		return fs.ErrExist
+	}
+	dir.children[name] = &node{
+		name:     name,
+		isDir:    true,
+		mode:     fs.ModeDir | 0755,
+		modTime:  time.Now(),
+		children: make(map[string]*node),
+	}
+	return nil
+}
+
+func (p *Producer) ReadDir(path string) ([]fs.FileInfo, error) {
+	p.delay()
+	if err := p.readErr(path); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	n, err := p.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isDir {
+		return nil, fs.ErrInvalid
+	}
+	infos := make([]fs.FileInfo, 0, len(n.children))
+	for _, child := range n.children {
+		infos = append(infos, child.info())
+	}
+	return infos, nil
+}
+
+func (p *Producer) DeleteDir(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n, err := p.lookup(path)
+	if err != nil {
+		return err
+	}
+	if !n.isDir {
+		return fs.ErrInvalid
+	}
+	if len(n.children) > 0 {
+		return errDirNotEmpty(path)
+	}
+
+	dir, name, err := p.parent(path)
+	if err != nil {
+		return err
+	}
+	delete(dir.children, name)
+	return nil
+}
+
+func errDirNotEmpty(path string) error {
+	return &fs.PathError{Op: "rmdir", Path: path, Err: errors.New("directory not empty")}
+}
+
+// MakedirAll creates a directory named path, along with any necessary
+// parents, and returns nil, or else returns an error. If path is already a
+// directory, MakedirAll does nothing and returns nil. If path already exists
+// as a regular file, MakedirAll returns an *os.PathError wrapping
+// syscall.ENOTDIR, matching ftp.MkdirAll and sftp's clientSFTP.MkdirAll.
+func (p *Producer) MakedirAll(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := p.root
+	for _, part := range split(path) {
+		if !n.isDir {
+			return fs.ErrInvalid
+		}
+		child, ok := n.children[part]
+		if !ok {
+			child = &node{
+				name:     part,
+				isDir:    true,
+				mode:     fs.ModeDir | 0755,
+				modTime:  time.Now(),
+				children: make(map[string]*node),
+			}
+			n.children[part] = child
+		}
+		n = child
+	}
+	if !n.isDir {
+		return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+func (p *Producer) Rename(oldname, newname string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n, err := p.lookup(oldname)
+	if err != nil {
+		return err
+	}
+	oldDir, _, err := p.parent(oldname)
+	if err != nil {
+		return err
+	}
+	newDir, newBase, err := p.parent(newname)
+	if err != nil {
+		return err
+	}
+
+	n.name = newBase
+	newDir.children[newBase] = n
+	delete(oldDir.children, path.Base(clean(oldname)))
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (p *Producer) Remove(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n, err := p.lookup(path)
+	if err != nil {
+		return err
+	}
+	if n.isDir && len(n.children) > 0 {
+		return errDirNotEmpty(path)
+	}
+	dir, name, err := p.parent(path)
+	if err != nil {
+		return err
+	}
+	delete(dir.children, name)
+	return nil
+}
+
+func (p *Producer) RemoveAll(path string) error {
+	return producer.RemoveAllConcurrent(p, path, removeConcurrentWorkers)
+}
+
+func (p *Producer) Walk(root string, fn producer.WalkFunc) error {
+	return producer.Walk(p, root, fn)
+}
+
+func (p *Producer) WalkN(root string, workers int, fn producer.WalkFunc) error {
+	return producer.WalkN(p, root, workers, fn)
+}
+
+// info returns the fs.FileInfo view of n.
+func (n *node) info() fs.FileInfo {
+	return fileInfo{
+		name:    n.name,
+		size:    int64(len(n.content)),
+		mode:    n.mode,
+		modTime: n.modTime,
+		isDir:   n.isDir,
+	}
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	isDir   bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.isDir }
+func (fi fileInfo) Sys() any           { return nil }
+
+// writer is the io.WriteCloser returned by Create: it buffers writes and
+// replaces the target file's content on Close, so a write that is aborted
+// (ctx canceled, or Close never called) never partially overwrites the file.
+type writer struct {
+	p    *Producer
+	dir  *node
+	name string
+	ctx  context.Context
+	buf  bytes.Buffer
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.buf.Write(b)
+}
+
+func (w *writer) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	w.p.mu.Lock()
+	defer w.p.mu.Unlock()
+
+	n, exists := w.dir.children[w.name]
+	if !exists {
+		n = &node{name: w.name, mode: 0644, modTime: time.Now()}
+		w.dir.children[w.name] = n
+	}
+	n.content = w.buf.Bytes()
+	n.modTime = time.Now()
+	return nil
+}
@@ -0,0 +1,160 @@
+package memfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestSaveFileAndReadFile(t *testing.T) {
+	p := NewProducer()
+
+	if err := p.MakedirAll("/dir"); err != nil {
+		t.Fatalf("MakedirAll: %v", err)
+	}
+	if err := p.SaveFile("/dir/file.txt", io.NopCloser(strings.NewReader("hello"))); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	rc, err := p.ReadFile("/dir/file.txt")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("content = %q, want %q", got, "hello")
+	}
+}
+
+func TestMakedirAllAndStat(t *testing.T) {
+	p := NewProducer()
+
+	if err := p.MakedirAll("/a/b/c"); err != nil {
+		t.Fatalf("MakedirAll: %v", err)
+	}
+
+	info, err := p.Stat("/a/b/c")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if !info.IsDir() {
+		t.Fatalf("Stat(%q).IsDir() = false, want true", "/a/b/c")
+	}
+
+	if _, err := p.Stat("/a/b/nope"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat of missing path: err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestMakedirAllOnExistingFileErrors(t *testing.T) {
+	p := NewProducer()
+
+	if err := p.SaveFile("/file.txt", io.NopCloser(strings.NewReader("x"))); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	if err := p.MakedirAll("/file.txt"); err == nil {
+		t.Fatal("MakedirAll on a path that is a regular file: got nil error, want non-nil")
+	}
+
+	info, err := p.Stat("/file.txt")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.IsDir() {
+		t.Fatal("MakedirAll turned an existing regular file into a directory")
+	}
+}
+
+func TestRemoveAllRemovesTree(t *testing.T) {
+	p := NewProducer()
+
+	if err := p.MakedirAll("/root/a"); err != nil {
+		t.Fatalf("MakedirAll: %v", err)
+	}
+	if err := p.MakedirAll("/root/b"); err != nil {
+		t.Fatalf("MakedirAll: %v", err)
+	}
+	for _, path := range []string{"/root/a/1.txt", "/root/a/2.txt", "/root/b/3.txt"} {
+		if err := p.SaveFile(path, io.NopCloser(strings.NewReader("x"))); err != nil {
+			t.Fatalf("SaveFile(%q): %v", path, err)
+		}
+	}
+
+	if err := p.RemoveAll("/root"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+
+	if _, err := p.Stat("/root"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat after RemoveAll: err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestSetReadErr(t *testing.T) {
+	p := NewProducer()
+	if err := p.SaveFile("/file.txt", io.NopCloser(strings.NewReader("x"))); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	wantErr := errors.New("boom")
+	p.SetReadErr("/file.txt", wantErr)
+
+	if _, err := p.ReadFile("/file.txt"); !errors.Is(err, wantErr) {
+		t.Fatalf("ReadFile: err = %v, want %v", err, wantErr)
+	}
+
+	p.SetReadErr("/file.txt", nil)
+	if _, err := p.ReadFile("/file.txt"); err != nil {
+		t.Fatalf("ReadFile after clearing fault: %v", err)
+	}
+}
+
+func TestCreateCancelDiscardsWrite(t *testing.T) {
+	p := NewProducer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w, err := p.Create(ctx, "/file.txt")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Fatal("Close on canceled ctx: got nil error, want non-nil")
+	}
+
+	if _, err := p.Stat("/file.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat after canceled write: err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestFs(t *testing.T) {
+	p := NewProducer()
+	if err := p.MakedirAll("/a"); err != nil {
+		t.Fatalf("MakedirAll: %v", err)
+	}
+	if err := p.SaveFile("/a/b.txt", io.NopCloser(strings.NewReader("hi"))); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	root := p.Fs()
+	a, ok := root.Children["a"]
+	if !ok || !a.IsDir {
+		t.Fatalf("Fs() root.Children[\"a\"] = %+v, ok=%v, want a directory", a, ok)
+	}
+	b, ok := a.Children["b.txt"]
+	if !ok || b.IsDir {
+		t.Fatalf("Fs() a.Children[\"b.txt\"] = %+v, ok=%v, want a file", b, ok)
+	}
+	if string(b.Content) != "hi" {
+		t.Fatalf("Content = %q, want %q", b.Content, "hi")
+	}
+}
@@ -0,0 +1,289 @@
+// Package pacer wraps a producer.Producer and retries transient errors
+// (dropped connections, timeouts, throttling) with exponential backoff and
+// jitter, modeled on rclone's lib/pacer. It also supports automatic
+// reconnection of the underlying client when the connection appears dead.
+package pacer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+
+	gosftp "github.com/pkg/sftp"
+
+	producer "github.com/NuclearLouse/file-producer"
+)
+
+// Default settings used when the corresponding Option is not supplied.
+const (
+	DefaultMinSleep      = 100 * time.Millisecond
+	DefaultMaxSleep      = 10 * time.Second
+	DefaultDecayConstant = 2
+	DefaultMaxRetries    = 5
+)
+
+// Pacer wraps a producer.Producer, retrying operations that fail with a
+// transient error. It implements producer.Producer itself, so it can be used
+// as a drop-in replacement for the wrapped client.
+type Pacer struct {
+	minSleep      time.Duration
+	maxSleep      time.Duration
+	decayConstant uint
+	maxRetries    int
+	shouldRetry   func(error) bool
+	reconnect     func() (producer.Producer, error)
+
+	mu sync.Mutex
+	p  producer.Producer
+}
+
+// Option configures a Pacer created by Wrap.
+type Option func(*Pacer)
+
+// MinSleep sets the minimum time to sleep before the first retry.
+func MinSleep(d time.Duration) Option {
+	return func(p *Pacer) { p.minSleep = d }
+}
+
+// MaxSleep sets the maximum time to sleep between retries.
+func MaxSleep(d time.Duration) Option {
+	return func(p *Pacer) { p.maxSleep = d }
+}
+
+// DecayConstant sets how fast the backoff grows between retries: after the
+// first retry, sleep time grows by a factor of roughly 2^DecayConstant with
+// each further retry.
+func DecayConstant(decay uint) Option {
+	return func(p *Pacer) { p.decayConstant = decay }
+}
+
+// MaxRetries sets the maximum number of retries per call, not counting the
+// initial attempt.
+func MaxRetries(n int) Option {
+	return func(p *Pacer) { p.maxRetries = n }
+}
+
+// ShouldRetry overrides the function used to decide whether an error is
+// transient and worth retrying. The default is DefaultShouldRetry.
+func ShouldRetry(fn func(error) bool) Option {
+	return func(p *Pacer) { p.shouldRetry = fn }
+}
+
+// Reconnect enables automatic reconnection: if Ping keeps failing after
+// MaxRetries, reconnect is called to obtain a fresh producer.Producer, which
+// replaces the one currently wrapped.
+func Reconnect(reconnect func() (producer.Producer, error)) Option {
+	return func(p *Pacer) { p.reconnect = reconnect }
+}
+
+// Wrap returns a producer.Producer that retries p's operations according to
+// opts.
+func Wrap(p producer.Producer, opts ...Option) producer.Producer {
+	pc := &Pacer{
+		minSleep:      DefaultMinSleep,
+		maxSleep:      DefaultMaxSleep,
+		decayConstant: DefaultDecayConstant,
+		maxRetries:    DefaultMaxRetries,
+		shouldRetry:   DefaultShouldRetry,
+		p:             p,
+	}
+	for _, opt := range opts {
+		opt(pc)
+	}
+	return pc
+}
+
+// DefaultShouldRetry reports whether err looks transient: an EOF, a
+// temporary net.Error, a 4xx FTP reply, or an SSH_FX_FAILURE /
+// SSH_FX_CONNECTION_LOST SFTP status.
+func DefaultShouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Temporary() {
+		return true
+	}
+
+	var ftpErr *textproto.Error
+	if errors.As(err, &ftpErr) && ftpErr.Code >= 400 && ftpErr.Code < 500 {
+		return true
+	}
+
+	var sftpErr *gosftp.StatusError
+	if errors.As(err, &sftpErr) {
+		switch sftpErr.FxCode() {
+		case gosftp.ErrSSHFxFailure, gosftp.ErrSSHFxConnectionLost:
+			return true
+		}
+	}
+
+	return false
+}
+
+// current returns the producer currently wrapped, guarded against a
+// concurrent reconnect.
+func (p *Pacer) current() producer.Producer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.p
+}
+
+// call runs op, retrying according to p's backoff settings while
+// p.shouldRetry(err) is true.
+func (p *Pacer) call(op func(producer.Producer) error) error {
+	var err error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		err = op(p.current())
+		if err == nil || !p.shouldRetry(err) {
+			return err
+		}
+		if attempt == p.maxRetries {
+			break
+		}
+		time.Sleep(p.sleepTime(attempt))
+	}
+	return err
+}
+
+// sleepTime computes the exponential backoff with jitter for the given
+// (zero-based) retry attempt.
+func (p *Pacer) sleepTime(attempt int) time.Duration {
+	if attempt == 0 {
+		return p.minSleep
+	}
+	sleep := float64(p.minSleep) * math.Pow(2, float64(p.decayConstant)*float64(attempt))
+	jitter := sleep / 4
+	sleep += jitter*2*rand.Float64() - jitter
+	d := time.Duration(sleep)
+	if d > p.maxSleep {
+		d = p.maxSleep
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// Ping retries the underlying Ping; if it still fails after MaxRetries and a
+// Reconnect option was supplied, a new client is dialed and swapped in.
+func (p *Pacer) Ping(path string) error {
+	err := p.call(func(pr producer.Producer) error { return pr.Ping(path) })
+	if err == nil || p.reconnect == nil {
+		return err
+	}
+	newProducer, rerr := p.reconnect()
+	if rerr != nil {
+		return err
+	}
+	p.mu.Lock()
+	old := p.p
+	p.p = newProducer
+	p.mu.Unlock()
+	old.Close()
+	return nil
+}
+
+func (p *Pacer) Close() error {
+	return p.current().Close()
+}
+
+func (p *Pacer) Stat(path string) (fs.FileInfo, error) {
+	var info fs.FileInfo
+	err := p.call(func(pr producer.Producer) error {
+		var err error
+		info, err = pr.Stat(path)
+		return err
+	})
+	return info, err
+}
+
+func (p *Pacer) ReadFile(path string) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	err := p.call(func(pr producer.Producer) error {
+		var err error
+		rc, err = pr.ReadFile(path)
+		return err
+	})
+	return rc, err
+}
+
+// Create is not retried: it streams, so a partial failure cannot simply be
+// replayed from the start without rewinding the caller's writes.
+func (p *Pacer) Create(ctx context.Context, path string) (io.WriteCloser, error) {
+	return p.current().Create(ctx, path)
+}
+
+// OpenReader is not retried: it streams, so a partial failure cannot simply
+// be replayed from the start without rewinding the caller's reads.
+func (p *Pacer) OpenReader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	return p.current().OpenReader(ctx, path, offset)
+}
+
+// SaveFile is not retried: it streams reader in a single pass, and the
+// backend closes it once consumed, so a retry would read from an
+// already-drained (and closed) reader instead of replaying the upload.
+func (p *Pacer) SaveFile(path string, reader io.ReadCloser) error {
+	return p.current().SaveFile(path, reader)
+}
+
+func (p *Pacer) DeleteFile(path string) error {
+	return p.call(func(pr producer.Producer) error { return pr.DeleteFile(path) })
+}
+
+func (p *Pacer) MakeDir(path string) error {
+	return p.call(func(pr producer.Producer) error { return pr.MakeDir(path) })
+}
+
+func (p *Pacer) ReadDir(path string) ([]fs.FileInfo, error) {
+	var infos []fs.FileInfo
+	err := p.call(func(pr producer.Producer) error {
+		var err error
+		infos, err = pr.ReadDir(path)
+		return err
+	})
+	return infos, err
+}
+
+func (p *Pacer) DeleteDir(path string) error {
+	return p.call(func(pr producer.Producer) error { return pr.DeleteDir(path) })
+}
+
+func (p *Pacer) MakedirAll(path string) error {
+	return p.call(func(pr producer.Producer) error { return pr.MakedirAll(path) })
+}
+
+func (p *Pacer) Rename(oldname, newname string) error {
+	return p.call(func(pr producer.Producer) error { return pr.Rename(oldname, newname) })
+}
+
+func (p *Pacer) Remove(path string) error {
+	return p.call(func(pr producer.Producer) error { return pr.Remove(path) })
+}
+
+func (p *Pacer) RemoveAll(path string) error {
+	return p.call(func(pr producer.Producer) error { return pr.RemoveAll(path) })
+}
+
+// Walk is delegated to the wrapped producer as-is: it streams results via fn
+// as it goes, so retrying the whole walk on a transient mid-tree error would
+// replay fn for entries already reported.
+func (p *Pacer) Walk(root string, fn producer.WalkFunc) error {
+	return p.current().Walk(root, fn)
+}
+
+// WalkN is not retried as a whole, for the same reason as Walk.
+func (p *Pacer) WalkN(root string, workers int, fn producer.WalkFunc) error {
+	return p.current().WalkN(root, workers, fn)
+}
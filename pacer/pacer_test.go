@@ -0,0 +1,190 @@
+package pacer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	producer "github.com/NuclearLouse/file-producer"
+)
+
+// fakeProducer is a minimal producer.Producer whose Stat and Ping results
+// are scripted by a queue of errors, for exercising Pacer's retry and
+// reconnect logic without a real backend.
+type fakeProducer struct {
+	statErrs  []error
+	statCalls int
+
+	pingErrs  []error
+	pingCalls int
+
+	saveErrs     []error
+	saveCalls    int
+	savedContent []byte
+
+	closed bool
+}
+
+func (f *fakeProducer) Ping(path string) error {
+	i := f.pingCalls
+	f.pingCalls++
+	if i < len(f.pingErrs) {
+		return f.pingErrs[i]
+	}
+	return nil
+}
+
+func (f *fakeProducer) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeProducer) Stat(path string) (fs.FileInfo, error) {
+	i := f.statCalls
+	f.statCalls++
+	if i < len(f.statErrs) {
+		return nil, f.statErrs[i]
+	}
+	return nil, nil
+}
+
+func (f *fakeProducer) ReadFile(path string) (io.ReadCloser, error) { return nil, nil }
+func (f *fakeProducer) Create(ctx context.Context, path string) (io.WriteCloser, error) {
+	return nil, nil
+}
+func (f *fakeProducer) OpenReader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	return nil, nil
+}
+func (f *fakeProducer) SaveFile(path string, reader io.ReadCloser) error {
+	i := f.saveCalls
+	f.saveCalls++
+	if reader != nil {
+		b, _ := io.ReadAll(reader)
+		f.savedContent = b
+		reader.Close()
+	}
+	if i < len(f.saveErrs) {
+		return f.saveErrs[i]
+	}
+	return nil
+}
+func (f *fakeProducer) DeleteFile(path string) error                 { return nil }
+func (f *fakeProducer) MakeDir(path string) error                    { return nil }
+func (f *fakeProducer) ReadDir(path string) ([]fs.FileInfo, error)   { return nil, nil }
+func (f *fakeProducer) DeleteDir(path string) error                  { return nil }
+func (f *fakeProducer) MakedirAll(path string) error                 { return nil }
+func (f *fakeProducer) Rename(oldname, newname string) error         { return nil }
+func (f *fakeProducer) Remove(path string) error                     { return nil }
+func (f *fakeProducer) RemoveAll(path string) error                  { return nil }
+func (f *fakeProducer) Walk(root string, fn producer.WalkFunc) error { return nil }
+func (f *fakeProducer) WalkN(root string, workers int, fn producer.WalkFunc) error {
+	return nil
+}
+
+func TestCallRetriesUntilSuccess(t *testing.T) {
+	fp := &fakeProducer{statErrs: []error{io.EOF, io.EOF}}
+	p := Wrap(fp, MinSleep(time.Millisecond), MaxSleep(2*time.Millisecond), MaxRetries(5))
+
+	if _, err := p.Stat("/x"); err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fp.statCalls != 3 {
+		t.Fatalf("statCalls = %d, want 3 (2 failures + 1 success)", fp.statCalls)
+	}
+}
+
+func TestCallGivesUpAfterMaxRetries(t *testing.T) {
+	fp := &fakeProducer{statErrs: []error{io.EOF, io.EOF, io.EOF, io.EOF}}
+	p := Wrap(fp, MinSleep(time.Millisecond), MaxSleep(2*time.Millisecond), MaxRetries(2))
+
+	if _, err := p.Stat("/x"); err == nil {
+		t.Fatal("Stat: got nil error, want non-nil")
+	}
+	if fp.statCalls != 3 {
+		t.Fatalf("statCalls = %d, want 3 (1 initial + 2 retries)", fp.statCalls)
+	}
+}
+
+func TestShouldRetryGateStopsNonRetryableError(t *testing.T) {
+	permanent := errors.New("permanent failure")
+	fp := &fakeProducer{statErrs: []error{permanent}}
+	p := Wrap(fp, MinSleep(time.Millisecond), MaxRetries(5))
+
+	if _, err := p.Stat("/x"); !errors.Is(err, permanent) {
+		t.Fatalf("Stat: err = %v, want %v", err, permanent)
+	}
+	if fp.statCalls != 1 {
+		t.Fatalf("statCalls = %d, want 1 (non-retryable error must not be retried)", fp.statCalls)
+	}
+}
+
+func TestPingReconnectsAndClosesOldProducer(t *testing.T) {
+	fp := &fakeProducer{pingErrs: []error{io.EOF, io.EOF, io.EOF}}
+	fp2 := &fakeProducer{}
+	reconnectCalls := 0
+
+	p := Wrap(fp,
+		MinSleep(time.Millisecond), MaxSleep(2*time.Millisecond), MaxRetries(1),
+		Reconnect(func() (producer.Producer, error) {
+			reconnectCalls++
+			return fp2, nil
+		}),
+	)
+
+	if err := p.Ping("/x"); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if reconnectCalls != 1 {
+		t.Fatalf("reconnectCalls = %d, want 1", reconnectCalls)
+	}
+	if !fp.closed {
+		t.Fatal("old producer was not closed after reconnect")
+	}
+
+	if err := p.Ping("/x"); err != nil {
+		t.Fatalf("Ping after reconnect: %v", err)
+	}
+	if fp2.pingCalls != 1 {
+		t.Fatalf("fp2.pingCalls = %d, want 1 (calls should go to the new producer)", fp2.pingCalls)
+	}
+}
+
+func TestSaveFileIsNotRetried(t *testing.T) {
+	fp := &fakeProducer{saveErrs: []error{io.EOF}}
+	p := Wrap(fp, MinSleep(time.Millisecond), MaxSleep(2*time.Millisecond), MaxRetries(5))
+
+	reader := io.NopCloser(strings.NewReader("0123456789"))
+	err := p.SaveFile("/x", reader)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("SaveFile: err = %v, want io.EOF (a transient failure must surface, not be retried)", err)
+	}
+	if fp.saveCalls != 1 {
+		t.Fatalf("saveCalls = %d, want 1 (retrying SaveFile replays an already-drained reader)", fp.saveCalls)
+	}
+	if string(fp.savedContent) != "0123456789" {
+		t.Fatalf("savedContent = %q, want the full unreplayed payload %q", fp.savedContent, "0123456789")
+	}
+}
+
+func TestDefaultShouldRetry(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"eof", io.EOF, true},
+		{"plain error", errors.New("boom"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DefaultShouldRetry(c.err); got != c.want {
+				t.Fatalf("DefaultShouldRetry(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
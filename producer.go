@@ -1,49 +1,79 @@
-// Interface for operations with files via remote protocols sftp, ftp
-package producer
-
-import (
-	"io"
-	"io/fs"
-)
-
-type Producer interface {
-	Ping(path string) error
-	Close() error
-
-	// Open(path string) (io.ReadWriter, error)
-	// OpenFile(path string, f int) (io.ReadWriter, error)
-	// Create(path string) (io.WriteCloser, error)
-
-	Stat(path string) (fs.FileInfo, error)
-	ReadFile(path string) (io.ReadCloser, error)
-
-	// SaveFile writes data to the named file, creating it if necessary.
-	// If the file does not exist, SaveFile creates it with permissions perm (before umask);
-	// otherwise SaveFile truncates it before writing, without changing permissions.
-	// To create an empty file instead of the Reader, pass the nil.
-	// Hint: io.Pipe() can be used if an io.Writer is required.
-	SaveFile(path string, reader io.ReadCloser) error
-	DeleteFile(path string) error
-
-	MakeDir(path string) error
-	ReadDir(path string) ([]fs.FileInfo, error)
-	DeleteDir(path string) error
-
-	// MkdirAll creates a directory named path, along with any necessary parents,
-	// and returns nil, or else returns an error.
-	// If path is already a directory, MkdirAll does nothing and returns nil.
-	// If path contains a regular file, an error is returned
-	MakedirAll(path string) error
-
-	//Rename file or directory
-	Rename(oldname, newname string) error
-
-	//Remove removes the named file or empty directory.
-	//An error will be returned if no file or directory with the specified path exists, or if the specified directory is not empty.
-	//If there is an other error, the error chain will contain fs.ErrInvalid
-	Remove(path string) error
-
-	//RemoveAll removes path and any children it contains. It removes everything it can but returns the first error it encounters.
-	//If the path does not exist, RemoveAll returns nil (no error). If there is an other error, the error chain maybe contain fs.ErrInvalid
-	RemoveAll(path string) error
-}
+// Interface for operations with files via remote protocols sftp, ftp
+package producer
+
+import (
+	"context"
+	"io"
+	"io/fs"
+)
+
+type Producer interface {
+	Ping(path string) error
+	Close() error
+
+	Stat(path string) (fs.FileInfo, error)
+	ReadFile(path string) (io.ReadCloser, error)
+
+	// Create opens the named file for writing, creating it if necessary and
+	// truncating it otherwise, and returns it for streaming writes. Closing
+	// the returned io.WriteCloser flushes and releases the underlying
+	// connection. Canceling ctx unblocks a write in progress by closing it.
+	Create(ctx context.Context, path string) (io.WriteCloser, error)
+
+	// OpenReader opens the named file for reading starting at offset and
+	// returns it for streaming reads. Canceling ctx unblocks a read in
+	// progress by closing it.
+	OpenReader(ctx context.Context, path string, offset int64) (io.ReadCloser, error)
+
+	// SaveFile writes data to the named file, creating it if necessary.
+	// If the file does not exist, SaveFile creates it with permissions perm (before umask);
+	// otherwise SaveFile truncates it before writing, without changing permissions.
+	// To create an empty file instead of the Reader, pass the nil.
+	// SaveFile streams reader directly to the destination; it never buffers
+	// the whole payload in memory.
+	SaveFile(path string, reader io.ReadCloser) error
+	DeleteFile(path string) error
+
+	MakeDir(path string) error
+	ReadDir(path string) ([]fs.FileInfo, error)
+	DeleteDir(path string) error
+
+	// MkdirAll creates a directory named path, along with any necessary parents,
+	// and returns nil, or else returns an error.
+	// If path is already a directory, MkdirAll does nothing and returns nil.
+	// If path contains a regular file, an error is returned
+	MakedirAll(path string) error
+
+	//Rename file or directory
+	Rename(oldname, newname string) error
+
+	//Remove removes the named file or empty directory.
+	//An error will be returned if no file or directory with the specified path exists, or if the specified directory is not empty.
+	//If there is an other error, the error chain will contain fs.ErrInvalid
+	Remove(path string) error
+
+	//RemoveAll removes path and any children it contains. It removes everything it can but returns the first error it encounters.
+	//If the path does not exist, RemoveAll returns nil (no error). If there is an other error, the error chain maybe contain fs.ErrInvalid
+	RemoveAll(path string) error
+
+	// Walk walks the file tree rooted at root, calling fn for each file or
+	// directory in the tree, including root itself. It behaves like
+	// filepath.Walk: a non-nil err passed to fn comes from Stat or ReadDir,
+	// and fn returning filepath.SkipDir skips the rest of a directory's
+	// entries (or, for a file, the rest of its containing directory).
+	Walk(root string, fn WalkFunc) error
+
+	// WalkN is like Walk but fans the traversal out across workers
+	// goroutines, each pulling directories off a shared queue and calling
+	// ReadDir independently. This is where the concurrency pays off: pkg/sftp
+	// pipelines ReadDir requests over the same SSH channel, and a pooled FTP
+	// client can run multiple LIST commands in parallel. Entries from a
+	// single directory are reported in ReadDir's order, but the order across
+	// directories is unspecified. As with Walk, fn returning filepath.SkipDir
+	// prunes the directory it was called for.
+	WalkN(root string, workers int, fn WalkFunc) error
+}
+
+// WalkFunc is the type of the function called by Walk and WalkN for each
+// file or directory visited.
+type WalkFunc func(path string, info fs.FileInfo, err error) error
@@ -0,0 +1,49 @@
+package producer_test
+
+import (
+	"net/url"
+	"testing"
+
+	producer "github.com/NuclearLouse/file-producer"
+	"github.com/NuclearLouse/file-producer/memfs"
+)
+
+func TestRegisterAndOpen(t *testing.T) {
+	var gotURL *url.URL
+	producer.Register("regtest", func(u *url.URL) (producer.Producer, error) {
+		gotURL = u
+		return memfs.NewProducer(), nil
+	})
+
+	p, err := producer.Open("regtest://host/path?x=1")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if p == nil {
+		t.Fatal("Open: got nil Producer")
+	}
+	if gotURL == nil || gotURL.Host != "host" {
+		t.Fatalf("factory received URL %v, want host %q", gotURL, "host")
+	}
+}
+
+func TestOpenUnknownScheme(t *testing.T) {
+	if _, err := producer.Open("doesnotexist://host"); err == nil {
+		t.Fatal("Open with unregistered scheme: got nil error, want non-nil")
+	}
+}
+
+func TestRegisterTwiceForSameSchemePanics(t *testing.T) {
+	producer.Register("regtest-dup", func(u *url.URL) (producer.Producer, error) {
+		return memfs.NewProducer(), nil
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Register called twice for the same scheme: got no panic")
+		}
+	}()
+	producer.Register("regtest-dup", func(u *url.URL) (producer.Producer, error) {
+		return memfs.NewProducer(), nil
+	})
+}
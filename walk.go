@@ -0,0 +1,272 @@
+package producer
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Walk implements Producer.Walk against any Producer, using only Stat and
+// ReadDir. Backend Walk methods are expected to be thin wrappers around it.
+func Walk(p Producer, root string, fn WalkFunc) error {
+	info, err := p.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	err = walk(p, root, info, fn)
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walk(p Producer, path string, info fs.FileInfo, fn WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	entries, err := p.ReadDir(path)
+	if err := fn(path, info, err); err != nil || entries == nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := walk(p, joinPath(path, entry.Name()), entry, fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkN implements Producer.WalkN against any Producer: workers goroutines
+// pull directories off a shared queue and call p.ReadDir independently.
+func WalkN(p Producer, root string, workers int, fn WalkFunc) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	rootInfo, err := p.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	if err := fn(root, rootInfo, nil); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	if !rootInfo.IsDir() {
+		return nil
+	}
+
+	q := newDirQueue()
+	var pending sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+
+	pending.Add(1)
+	q.push(root)
+
+	go func() {
+		pending.Wait()
+		q.close()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				path, ok := q.pop()
+				if !ok {
+					return
+				}
+				walkDirN(p, path, &pending, q, fn, setErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// walkDirN processes a single directory job: it lists path, reports each
+// entry to fn, and enqueues any subdirectories for another worker to pick up.
+func walkDirN(p Producer, path string, pending *sync.WaitGroup, q *dirQueue, fn WalkFunc, setErr func(error)) {
+	defer pending.Done()
+
+	entries, err := p.ReadDir(path)
+	if err != nil {
+		if ferr := fn(path, nil, err); ferr != nil && ferr != filepath.SkipDir {
+			setErr(ferr)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		childPath := joinPath(path, entry.Name())
+		ferr := fn(childPath, entry, nil)
+		if ferr == filepath.SkipDir {
+			continue
+		}
+		if ferr != nil {
+			setErr(ferr)
+			continue
+		}
+		if entry.IsDir() {
+			pending.Add(1)
+			q.push(childPath)
+		}
+	}
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return strings.TrimRight(dir, "/") + "/" + name
+}
+
+// dirQueue is an unbounded FIFO queue of directory paths awaiting a ReadDir,
+// used by WalkN in place of a fixed-size channel so a wide, shallow tree
+// can't deadlock a small worker pool.
+type dirQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []string
+	closed bool
+}
+
+func newDirQueue() *dirQueue {
+	q := &dirQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *dirQueue) push(path string) {
+	q.mu.Lock()
+	q.items = append(q.items, path)
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+func (q *dirQueue) pop() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return "", false
+	}
+	path := q.items[0]
+	q.items = q.items[1:]
+	return path, true
+}
+
+func (q *dirQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// removeEntry is one path collected while walking a tree for removal.
+type removeEntry struct {
+	path  string
+	isDir bool
+	depth int
+}
+
+// RemoveAllConcurrent removes root and any children it contains. It walks
+// the tree with WalkN (workers goroutines) and then deletes bottom-up, one
+// depth level at a time, so every child is gone before its parent is
+// removed; entries that share a depth are deleted concurrently, at most
+// workers at a time. It removes everything it can but returns the first
+// error it encounters. If root does not exist, RemoveAllConcurrent returns nil.
+func RemoveAllConcurrent(p Producer, root string, workers int) error {
+	if root == "" {
+		return nil
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		entries []removeEntry
+	)
+
+	err := WalkN(p, root, workers, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		mu.Lock()
+		entries = append(entries, removeEntry{path, info.IsDir(), strings.Count(strings.TrimRight(path, "/"), "/")})
+		mu.Unlock()
+		return nil
+	})
+	var firstErr error
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		firstErr = err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].depth > entries[j].depth })
+
+	sem := make(chan struct{}, workers)
+	for i := 0; i < len(entries); {
+		j := i
+		for j < len(entries) && entries[j].depth == entries[i].depth {
+			j++
+		}
+
+		var wg sync.WaitGroup
+		var levelMu sync.Mutex
+		for _, e := range entries[i:j] {
+			e := e
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				var err error
+				if e.isDir {
+					err = p.DeleteDir(e.path)
+				} else {
+					err = p.DeleteFile(e.path)
+				}
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					levelMu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					levelMu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		i = j
+	}
+
+	return firstErr
+}
@@ -0,0 +1,138 @@
+package producer_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	producer "github.com/NuclearLouse/file-producer"
+	"github.com/NuclearLouse/file-producer/memfs"
+)
+
+func buildTree(t *testing.T, p *memfs.Producer) {
+	t.Helper()
+	dirs := []string{"/root/a", "/root/b"}
+	files := []string{"/root/1.txt", "/root/a/2.txt", "/root/b/3.txt"}
+	for _, d := range dirs {
+		if err := p.MakedirAll(d); err != nil {
+			t.Fatalf("MakedirAll(%q): %v", d, err)
+		}
+	}
+	for _, f := range files {
+		if err := p.SaveFile(f, io.NopCloser(strings.NewReader("x"))); err != nil {
+			t.Fatalf("SaveFile(%q): %v", f, err)
+		}
+	}
+}
+
+func TestWalkVisitsAllEntries(t *testing.T) {
+	p := memfs.NewProducer()
+	buildTree(t, p)
+
+	var mu sync.Mutex
+	var seen []string
+	err := p.Walk("/root", func(path string, info fs.FileInfo, err error) error {
+		mu.Lock()
+		seen = append(seen, path)
+		mu.Unlock()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	sort.Strings(seen)
+	want := []string{"/root", "/root/1.txt", "/root/a", "/root/a/2.txt", "/root/b", "/root/b/3.txt"}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Fatalf("Walk visited %v, want %v", seen, want)
+	}
+}
+
+func TestWalkSkipDirOnNonDirRoot(t *testing.T) {
+	p := memfs.NewProducer()
+	if err := p.SaveFile("/file.txt", io.NopCloser(strings.NewReader("x"))); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	err := p.Walk("/file.txt", func(path string, info fs.FileInfo, err error) error {
+		return filepath.SkipDir
+	})
+	if err != nil {
+		t.Fatalf("Walk returned %v, want nil (top-level SkipDir must be swallowed)", err)
+	}
+}
+
+func TestWalkSkipDirPrunesSubtree(t *testing.T) {
+	p := memfs.NewProducer()
+	buildTree(t, p)
+
+	var mu sync.Mutex
+	var seen []string
+	err := p.Walk("/root", func(path string, info fs.FileInfo, err error) error {
+		mu.Lock()
+		seen = append(seen, path)
+		mu.Unlock()
+		if path == "/root/a" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	for _, path := range seen {
+		if strings.HasPrefix(path, "/root/a/") {
+			t.Fatalf("Walk visited %q after SkipDir on /root/a", path)
+		}
+	}
+}
+
+func TestWalkNVisitsAllEntries(t *testing.T) {
+	p := memfs.NewProducer()
+	buildTree(t, p)
+
+	var mu sync.Mutex
+	var seen []string
+	err := p.WalkN("/root", 4, func(path string, info fs.FileInfo, err error) error {
+		mu.Lock()
+		seen = append(seen, path)
+		mu.Unlock()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WalkN: %v", err)
+	}
+
+	sort.Strings(seen)
+	want := []string{"/root", "/root/1.txt", "/root/a", "/root/a/2.txt", "/root/b", "/root/b/3.txt"}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Fatalf("WalkN visited %v, want %v", seen, want)
+	}
+}
+
+func TestRemoveAllConcurrentRemovesTree(t *testing.T) {
+	p := memfs.NewProducer()
+	buildTree(t, p)
+
+	if err := producer.RemoveAllConcurrent(p, "/root", 4); err != nil {
+		t.Fatalf("RemoveAllConcurrent: %v", err)
+	}
+
+	if _, err := p.Stat("/root"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat after RemoveAllConcurrent: err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestRemoveAllConcurrentMissingRootIsNotError(t *testing.T) {
+	p := memfs.NewProducer()
+
+	if err := producer.RemoveAllConcurrent(p, "/nope", 4); err != nil {
+		t.Fatalf("RemoveAllConcurrent on missing root: %v", err)
+	}
+}